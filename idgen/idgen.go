@@ -0,0 +1,12 @@
+// Package idgen generates the (tenant, record) ID pairs that drive the
+// benchmark's insert/read/update operations.
+package idgen
+
+// Generator produces the tenant and record ID to use for a new record, and
+// selects the tenant and record ID of a previously inserted one.
+type Generator interface {
+	// GetNew returns the (tenantID, id) pair to assign to a new record.
+	GetNew() (tenantID uint64, id uint64)
+	// GetExisting returns the (tenantID, id) pair of a previously inserted record.
+	GetExisting() (tenantID uint64, id uint64)
+}
@@ -0,0 +1,91 @@
+package idgen
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// TenantGenerator scopes record IDs to a fixed number of logical tenants.
+// Tenants are chosen with a Zipfian skew, so a handful of tenants receive
+// most of the traffic while the rest are touched rarely -- mirroring the
+// access pattern of a real multi-tenant SaaS workload, rather than spreading
+// operations evenly across a flat keyspace.
+type TenantGenerator struct {
+	tenants uint64
+	rnd     *rand.Rand
+
+	// mu guards zipf, since rand.Zipf.Uint64 mutates the *rand.Rand it was
+	// built from and neither is safe for concurrent use on its own.
+	mu   sync.Mutex
+	zipf *rand.Zipf
+
+	nextID uint64 // atomically incremented to hand out new record IDs
+	maxID  uint64 // highest ID known to exist, for picking an existing one
+}
+
+// NewTenantGenerator creates a generator over the given number of tenants.
+// skew controls the Zipf distribution: 0 picks tenants uniformly, and
+// increasing it makes the distribution more skewed toward tenant 0. rnd seeds
+// the draws. skew must be >= 0; math/rand.NewZipf requires s > 1, so skew+1
+// is passed as its "s" parameter, and skew == 0 is handled as a uniform pick
+// instead of being passed through.
+func NewTenantGenerator(tenants uint64, skew float64, rnd *rand.Rand) (*TenantGenerator, error) {
+	if skew < 0 {
+		return nil, fmt.Errorf("idgen: skew must be >= 0, got %v", skew)
+	}
+	if tenants == 0 {
+		tenants = 1
+	}
+
+	g := &TenantGenerator{tenants: tenants, rnd: rnd}
+	if skew > 0 && tenants > 1 {
+		g.zipf = rand.NewZipf(rnd, skew+1, 1, tenants-1)
+		if g.zipf == nil {
+			return nil, fmt.Errorf("idgen: invalid zipf parameters for skew %v over %d tenants", skew, tenants)
+		}
+	}
+	return g, nil
+}
+
+// pickTenant draws a tenant ID, either from the Zipf distribution or, for
+// skew == 0, uniformly across [0, tenants). Both branches draw from g.rnd,
+// guarded by g.mu, so a caller that seeds rnd gets a reproducible sequence
+// of tenant picks and *rand.Rand -- which isn't safe for concurrent use --
+// is never touched outside the lock.
+func (g *TenantGenerator) pickTenant() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.zipf == nil {
+		return uint64(g.rnd.Int63n(int64(g.tenants)))
+	}
+	return g.zipf.Uint64()
+}
+
+// GetNew returns the next (tenantID, id) pair to insert.
+func (g *TenantGenerator) GetNew() (uint64, uint64) {
+	id := atomic.AddUint64(&g.nextID, 1)
+	atomic.StoreUint64(&g.maxID, id)
+	return g.pickTenant(), id
+}
+
+// GetExisting returns a (tenantID, id) pair already handed out by GetNew.
+func (g *TenantGenerator) GetExisting() (uint64, uint64) {
+	max := atomic.LoadUint64(&g.maxID)
+	if max == 0 {
+		max = 1
+	}
+
+	tenantID := g.pickTenant()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return tenantID, uint64(g.rnd.Int63n(int64(max))) + 1
+}
+
+// Tenants returns the number of logical tenants this generator spans.
+func (g *TenantGenerator) Tenants() uint64 {
+	return g.tenants
+}
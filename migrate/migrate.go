@@ -0,0 +1,62 @@
+// Package migrate implements a small, provider-agnostic schema migration
+// runner: a sequence of versioned, idempotent Up steps, with the currently
+// applied version tracked by a Store (a schema_migrations collection or
+// table) so NewProvider can bring a fresh database up to date before the
+// benchmark starts, instead of assuming indexes already exist.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Version is a migration's identifier. Versions sort lexically, so callers
+// should zero-pad them (e.g. "0001", "0002", ... "0010") rather than using
+// bare integers.
+type Version string
+
+// Migration is a single, idempotent schema change. Up receives the version
+// the store was at before this migration runs, so a step can special-case
+// databases that already have some of its work done by a prior ad-hoc setup.
+type Migration struct {
+	Version     Version
+	Description string
+	Up          func(ctx context.Context, from Version) error
+}
+
+// Store records and retrieves the currently applied migration version.
+type Store interface {
+	CurrentVersion(ctx context.Context) (Version, error)
+	SetVersion(ctx context.Context, v Version) error
+}
+
+// Apply runs every migration in migrations whose Version is greater than
+// the version recorded in store, in ascending order, recording the new
+// version after each one commits. Migrations must be idempotent: Apply may
+// be called against a database that already has some (or all) of them
+// applied.
+func Apply(ctx context.Context, store Store, migrations []Migration) error {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	current, err := store.CurrentVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: reading current version: %w", err)
+	}
+
+	for _, m := range sorted {
+		if m.Version <= current {
+			continue
+		}
+		if err := m.Up(ctx, current); err != nil {
+			return fmt.Errorf("migrate: applying %s (%s): %w", m.Version, m.Description, err)
+		}
+		if err := store.SetVersion(ctx, m.Version); err != nil {
+			return fmt.Errorf("migrate: recording %s: %w", m.Version, err)
+		}
+		current = m.Version
+	}
+	return nil
+}
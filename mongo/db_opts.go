@@ -12,7 +12,9 @@ import (
 	"time"
 
 	"github.com/wardaBibi/mongo-postgres-benchmark/idgen"
+	"github.com/wardaBibi/mongo-postgres-benchmark/migrate"
 	"github.com/wardaBibi/mongo-postgres-benchmark/record"
+	"github.com/wardaBibi/mongo-postgres-benchmark/workload"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -24,6 +26,17 @@ import (
 type FuncProvider struct {
 	Client     *mongo.Client
 	Collection *mongo.Collection
+
+	// ReadConcern and WriteConcern are the concerns NewProvider parsed from
+	// the connection URL. RunTxn reuses them so transactions honor the same
+	// durability/consistency trade-off as every other operation.
+	ReadConcern  *readconcern.ReadConcern
+	WriteConcern *writeconcern.WriteConcern
+
+	// Unordered controls whether InsertBatch lets MongoDB keep inserting
+	// past a failed document (ordered=false) instead of aborting the rest
+	// of the batch. Set via the "unordered" URL param.
+	Unordered bool
 }
 
 // InsertRecord generates a new random record and inserts it with an ID provided
@@ -34,7 +47,7 @@ func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd
 	defer cancel()
 
 	data.Randomise(rnd)
-	data.ID = id.GetNew()
+	data.TenantID, data.ID = id.GetNew()
 
 	_, err := p.Collection.InsertOne(ctx, data)
 	if err != nil {
@@ -44,20 +57,48 @@ func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd
 	return true
 }
 
+// InsertBatch inserts data in a single InsertMany round trip, assigning each
+// record's ID positionally from ids. Callers are expected to have already
+// populated data (e.g. via Randomise and a TenantID) since, unlike
+// InsertRecord, InsertBatch has no idgen.Generator to do that itself -- the
+// load phase pre-generates IDs in bulk instead of one round trip per
+// record. With Unordered set, a failure partway through the batch doesn't
+// stop the remaining documents from being inserted.
+func (p *FuncProvider) InsertBatch(data []*record.Person, ids []uint64) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	docs := make([]interface{}, len(data))
+	for i, d := range data {
+		d.ID = ids[i]
+		docs[i] = d
+	}
+
+	opts := options.InsertMany().SetOrdered(!p.Unordered)
+	result, err := p.Collection.InsertMany(ctx, docs, opts)
+	if err != nil {
+		if bulkErr, ok := err.(mongo.BulkWriteException); ok {
+			return len(docs) - len(bulkErr.WriteErrors), err
+		}
+		return 0, err
+	}
+	return len(result.InsertedIDs), nil
+}
+
 // UpdateRecord attempts to update the record with ID returned by id.GetExisting.
 func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
 	// Get a new context with timeout (for cancellation, if necessary)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	recordID := id.GetExisting()
+	tenantID, recordID := id.GetExisting()
 
 	update := bson.M{
 		"$set": bson.M{"balance": rnd.Float32()},
 	}
-	_, err := p.Collection.UpdateOne(ctx, bson.M{"_id": recordID}, update)
+	_, err := p.Collection.UpdateOne(ctx, bson.M{"tenantID": tenantID, "_id": recordID}, update)
 	if err != nil {
-		log.Println(recordID, err)
+		log.Println(tenantID, recordID, err)
 		return false
 	}
 
@@ -70,12 +111,12 @@ func (p *FuncProvider) ReadRecord(_ *record.Person, id idgen.Generator, rnd *ran
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	recordID := id.GetExisting()
+	tenantID, recordID := id.GetExisting()
 	var data record.Person
 
-	err := p.Collection.FindOne(ctx, bson.M{"_id": recordID}).Decode(&data)
+	err := p.Collection.FindOne(ctx, bson.M{"tenantID": tenantID, "_id": recordID}).Decode(&data)
 	if err != nil {
-		log.Println(recordID, err)
+		log.Println(tenantID, recordID, err)
 		return false
 	}
 
@@ -137,6 +178,151 @@ func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator,
 	return true
 }
 
+// Execute runs a single workload.Op against the collection. It replaces the
+// fixed InsertRecord/UpdateRecord/ReadRecord/ReadRange/ReadMostRecentRecord
+// mix with whatever operation the workload engine picked, so a benchmark run
+// can exercise an arbitrary, JSON-configured set of filters without a
+// recompile.
+func (p *FuncProvider) Execute(op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return p.execute(ctx, op, data, id, rnd)
+}
+
+// execute runs op against the collection using the given context, so it can
+// be shared between the standalone Execute path and RunTxn's session context.
+func (p *FuncProvider) execute(ctx context.Context, op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	filter, err := workload.ParseFilter(op.Filter)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	bsonFilter := workload.ToBSON(filter)
+
+	switch op.Kind {
+	case workload.OpInsert:
+		data.Randomise(rnd)
+		data.TenantID, data.ID = id.GetNew()
+		if _, err := p.Collection.InsertOne(ctx, data); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpFind:
+		opts := options.Find()
+		if len(op.Sort) > 0 {
+			sort, err := workload.SortToBSON(op.Sort)
+			if err != nil {
+				log.Println(err)
+				return false
+			}
+			opts.SetSort(sort)
+		}
+		if op.Limit > 0 {
+			opts.SetLimit(int64(op.Limit))
+		}
+		if len(op.Projection) > 0 {
+			proj := bson.M{}
+			for _, field := range op.Projection {
+				proj[field] = 1
+			}
+			opts.SetProjection(proj)
+		}
+
+		cursor, err := p.Collection.Find(ctx, bsonFilter, opts)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var doc record.Person
+			if err := cursor.Decode(&doc); err != nil {
+				log.Println(err)
+				return false
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpUpdate:
+		sets, err := workload.ParseUpdate(op.Update)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		if _, err := p.Collection.UpdateMany(ctx, bsonFilter, workload.ToUpdateBSON(sets)); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpDelete:
+		if _, err := p.Collection.DeleteMany(ctx, bsonFilter); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpAggregate:
+		pipeline := bson.A{bson.M{"$match": bsonFilter}}
+		if op.Limit > 0 {
+			pipeline = append(pipeline, bson.M{"$limit": op.Limit})
+		}
+		cursor, err := p.Collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+		}
+		return cursor.Err() == nil
+
+	default:
+		log.Printf("unsupported workload op kind: %s", op.Kind)
+		return false
+	}
+}
+
+// RunTxn executes ops as a single multi-statement transaction via
+// client.StartSession + WithTransaction, honoring the ReadConcern and
+// WriteConcern the provider was configured with. The driver retries the
+// commit on transient or unknown-commit-result errors per WithTransaction's
+// usual semantics. An op that fails inside the transaction aborts it with a
+// plain error that carries no transient label, so that case is not retried.
+func (p *FuncProvider) RunTxn(ops []*workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sess, err := p.Client.StartSession()
+	if err != nil {
+		return fmt.Errorf("starting session: %w", err)
+	}
+	defer sess.EndSession(ctx)
+
+	txnOpts := options.Transaction().
+		SetReadConcern(p.ReadConcern).
+		SetWriteConcern(p.WriteConcern)
+
+	_, err = sess.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		for _, op := range ops {
+			if ok := p.execute(sc, op, data, id, rnd); !ok {
+				return nil, fmt.Errorf("txn op %s failed", op.Kind)
+			}
+		}
+		return nil, nil
+	}, txnOpts)
+
+	return err
+}
+
 // GetMaxID returns the largest ID in the collection.
 func (p *FuncProvider) GetMaxID() (uint64, error) {
 	// Get a new context with timeout (for cancellation, if necessary)
@@ -214,6 +400,18 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	}
 	q.Del("fsync")
 
+	// --- Unordered bulk inserts ---
+	var unordered bool
+	switch strings.ToLower(q.Get("unordered")) {
+	case "", "false", "0":
+		unordered = false
+	case "true", "1":
+		unordered = true
+	default:
+		return nil, errors.New("unknown unordered value")
+	}
+	q.Del("unordered")
+
 	// --- Clean up the URL for MongoDB ---
 	endpoint.RawQuery = q.Encode()
 
@@ -233,10 +431,19 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 
 	collection := client.Database(dbName).Collection(tableName)
 
+	store := &migrationStore{
+		collection:     client.Database(dbName).Collection("schema_migrations"),
+		collectionName: tableName,
+	}
+	if err := migrate.Apply(context.Background(), store, migrations(collection)); err != nil {
+		return nil, err
+	}
+
 	return &FuncProvider{
-		Client:     client,
-		Collection: collection,
+		Client:       client,
+		Collection:   collection,
+		ReadConcern:  rc,
+		WriteConcern: wc,
+		Unordered:    unordered,
 	}, nil
 }
-
-
@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/wardaBibi/mongo-postgres-benchmark/migrate"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationStore adapts a schema_migrations collection to migrate.Store. The
+// collection holds one document per benchmarked collection, keyed by
+// collectionName and recording the currently applied version, so distinct
+// collections in the same database track their versions independently
+// instead of a second NewProvider call reading the first collection's
+// already-applied version and skipping its own migrations.
+type migrationStore struct {
+	collection     *mongo.Collection
+	collectionName string
+}
+
+func (s *migrationStore) CurrentVersion(ctx context.Context) (migrate.Version, error) {
+	var doc struct {
+		Version migrate.Version `bson:"version"`
+	}
+	err := s.collection.FindOne(ctx, bson.M{"_id": s.collectionName}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return doc.Version, nil
+}
+
+func (s *migrationStore) SetVersion(ctx context.Context, v migrate.Version) error {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": s.collectionName},
+		bson.M{"$set": bson.M{"version": v}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// migrations are the built-in schema changes every fresh benchmark database
+// needs: the indexes the benchmark's operations currently assume exist
+// implicitly.
+func migrations(collection *mongo.Collection) []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version:     "0001",
+			Description: "index on age for ReadRange",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "age", Value: 1}},
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0002",
+			Description: "descending index on _id for ReadMostRecentRecord",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "_id", Value: -1}},
+				})
+				return err
+			},
+		},
+		{
+			Version:     "0003",
+			Description: "compound tenantID+_id index for tenant-scoped lookups",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{{Key: "tenantID", Value: 1}, {Key: "_id", Value: 1}},
+				})
+				return err
+			},
+		},
+	}
+}
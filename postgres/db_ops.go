@@ -8,31 +8,45 @@ import (
 	"net/url"
 	"runtime"
 	"strconv"
+	"strings"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/wardaBibi/mongo-postgres-benchmark/idgen"
+	"github.com/wardaBibi/mongo-postgres-benchmark/migrate"
 	"github.com/wardaBibi/mongo-postgres-benchmark/record"
-	"github.com/jackc/pgx/v4/pgxpool" 
+	"github.com/wardaBibi/mongo-postgres-benchmark/workload"
 	"golang.org/x/net/context"
 )
 
-// FuncProvider implements dbProvider for PostgreSQL using pgxpool.
-type FuncProvider struct {
+// JSONBProvider implements dbProvider for PostgreSQL using pgxpool.
+type JSONBProvider struct {
 	DB        *pgxpool.Pool // Change from pgx.Conn to pgxpool.Pool
 	TableName string
 }
 
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx, so Execute's query
+// logic can run unmodified whether it's issuing one-off statements or
+// running inside a RunTxn transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 // InsertRecord generates a new random record and inserts it with an ID provided
 // by id.GetNew as a JSON-encoded string.
-func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+func (p *JSONBProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
 	data.Randomise(rnd)
-	data.ID = id.GetNew()
+	data.TenantID, data.ID = id.GetNew()
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		panic(err)
 	}
 
-	_, err = p.DB.Exec(context.Background(), "INSERT INTO "+p.TableName+" (data) VALUES ($1)", string(jsonData))
+	_, err = p.DB.Exec(context.Background(), "INSERT INTO "+p.TableName+" (tenant_id, data) VALUES ($1, $2)", data.TenantID, string(jsonData))
 	if err != nil {
 		log.Println(err)
 		return false
@@ -41,18 +55,49 @@ func (p *FuncProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd
 	return true
 }
 
+// InsertBatch inserts data in a single round trip using a multi-statement
+// pgx.Batch, assigning each record's ID positionally from ids. Callers are
+// expected to have already populated data (e.g. via Randomise and a
+// TenantID) since, unlike InsertRecord, InsertBatch has no idgen.Generator
+// to do that itself -- the load phase pre-generates IDs in bulk instead of
+// one round trip per record.
+func (p *JSONBProvider) InsertBatch(data []*record.Person, ids []uint64) (int, error) {
+	batch := &pgx.Batch{}
+	for i, d := range data {
+		d.ID = ids[i]
+		jsonData, err := json.Marshal(d)
+		if err != nil {
+			return 0, err
+		}
+		batch.Queue("INSERT INTO "+p.TableName+" (tenant_id, data) VALUES ($1, $2)", d.TenantID, string(jsonData))
+	}
+
+	br := p.DB.SendBatch(context.Background(), batch)
+	defer br.Close()
+
+	n := 0
+	for range data {
+		if _, err := br.Exec(); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
 // UpdateRecord attempts to update the record with ID returned by
 // id.GetExisting.
-func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
-	recordID := id.GetExisting()
+func (p *JSONBProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	tenantID, recordID := id.GetExisting()
 	_, err := p.DB.Exec(
 		context.Background(),
-		"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{balance}', $1::jsonb, false) WHERE data->'id'=$2",
+		"UPDATE "+p.TableName+" SET data=jsonb_set(data, '{balance}', $1::jsonb, false) WHERE tenant_id=$2 AND data->'id'=$3",
 		strconv.FormatFloat(float64(rnd.Float32()), 'f', -1, 32),
+		tenantID,
 		recordID,
 	)
 	if err != nil {
-		log.Println(recordID, err)
+		log.Println(tenantID, recordID, err)
 		return false
 	}
 	return true
@@ -60,19 +105,19 @@ func (p *FuncProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *r
 
 // ReadRecord attempts to fetch the record with an ID returned by
 // id.GetExisting.
-func (p *FuncProvider) ReadRecord(_ *record.Person, id idgen.Generator, _ *rand.Rand) bool {
-	recordID := id.GetExisting()
+func (p *JSONBProvider) ReadRecord(_ *record.Person, id idgen.Generator, _ *rand.Rand) bool {
+	tenantID, recordID := id.GetExisting()
 
 	var rawData []byte
-	err := p.DB.QueryRow(context.Background(), "SELECT data FROM "+p.TableName+" WHERE data->'id'=$1", recordID).Scan(&rawData)
+	err := p.DB.QueryRow(context.Background(), "SELECT data FROM "+p.TableName+" WHERE tenant_id=$1 AND data->'id'=$2", tenantID, recordID).Scan(&rawData)
 	if err != nil {
-		log.Println(recordID, err)
+		log.Println(tenantID, recordID, err)
 		return false
 	}
 
 	var data = &record.Person{}
 	if err := json.Unmarshal(rawData, &data); err != nil {
-		log.Println(recordID, err)
+		log.Println(tenantID, recordID, err)
 		return false
 	}
 
@@ -80,7 +125,7 @@ func (p *FuncProvider) ReadRecord(_ *record.Person, id idgen.Generator, _ *rand.
 }
 
 // ReadRange performs a range query on the age field.
-func (p *FuncProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+func (p *JSONBProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
 	rows, err := p.DB.Query(context.Background(), "SELECT data FROM "+p.TableName+" WHERE (data->'age') > '45' AND (data->'age') < '75'")
 	if err != nil {
 		log.Println(err)
@@ -107,7 +152,7 @@ func (p *FuncProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Ra
 
 // ReadMostRecentRecord fetches the most recently inserted record by performing
 // a sort on the ID field, and limiting the results to a single record.
-func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+func (p *JSONBProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
 	var rawData []byte
 	err := p.DB.QueryRow(context.Background(), "SELECT data FROM "+p.TableName+" ORDER BY data->'id' DESC LIMIT 1").Scan(&rawData)
 	if err != nil {
@@ -124,8 +169,152 @@ func (p *FuncProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator,
 	return true
 }
 
+// Execute runs a single workload.Op against the table. It replaces the fixed
+// InsertRecord/UpdateRecord/ReadRecord/ReadRange/ReadMostRecentRecord mix
+// with whatever operation the workload engine picked, translating its
+// filter AST into a parameterized predicate against the JSONB data column.
+func (p *JSONBProvider) Execute(op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	return execute(context.Background(), p.DB, p.TableName, op, data, id, rnd)
+}
+
+// execute runs op against db using the given context, so the same query
+// logic can be shared between the standalone Execute path and RunTxn, which
+// passes a pgx.Tx in place of the pool.
+func execute(ctx context.Context, db querier, tableName string, op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	filter, err := workload.ParseFilter(op.Filter)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	switch op.Kind {
+	case workload.OpInsert:
+		data.Randomise(rnd)
+		data.TenantID, data.ID = id.GetNew()
+
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := db.Exec(ctx, "INSERT INTO "+tableName+" (tenant_id, data) VALUES ($1, $2)", data.TenantID, string(jsonData)); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpFind:
+		where, args := workload.ToSQL(filter, 0)
+		query := "SELECT data FROM " + tableName + " WHERE " + where
+		if len(op.Sort) > 0 {
+			orderBy, err := workload.SortToSQL(op.Sort)
+			if err != nil {
+				log.Println(err)
+				return false
+			}
+			query += " ORDER BY " + orderBy
+		}
+		if op.Limit > 0 {
+			query += " LIMIT " + strconv.Itoa(op.Limit)
+		}
+
+		rows, err := db.Query(ctx, query, args...)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer rows.Close()
+
+		var rawData []byte
+		var doc record.Person
+		for rows.Next() {
+			if err := rows.Scan(&rawData); err != nil {
+				log.Println(err)
+				return false
+			}
+			if err := json.Unmarshal(rawData, &doc); err != nil {
+				log.Println(err)
+				return false
+			}
+		}
+		return rows.Err() == nil
+
+	case workload.OpUpdate:
+		sets, err := workload.ParseUpdate(op.Update)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		setClause, setArgs := workload.ToUpdateSQL(sets, 0)
+		where, whereArgs := workload.ToSQL(filter, len(setArgs))
+		query := "UPDATE " + tableName + " SET data = " + setClause + " WHERE " + where
+		if _, err := db.Exec(ctx, query, append(setArgs, whereArgs...)...); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpDelete:
+		where, args := workload.ToSQL(filter, 0)
+		query := "DELETE FROM " + tableName + " WHERE " + where
+		if _, err := db.Exec(ctx, query, args...); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpAggregate:
+		// Mirrors Mongo's $match+$limit aggregation pipeline: fetch and
+		// drain the matching documents rather than counting them, so the
+		// same workload JSON benchmarks the same operation on both
+		// backends.
+		where, args := workload.ToSQL(filter, 0)
+		query := "SELECT data FROM " + tableName + " WHERE " + where
+		if op.Limit > 0 {
+			query += " LIMIT " + strconv.Itoa(op.Limit)
+		}
+
+		rows, err := db.Query(ctx, query, args...)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+		}
+		return rows.Err() == nil
+
+	default:
+		log.Printf("unsupported workload op kind: %s", op.Kind)
+		return false
+	}
+}
+
+// RunTxn executes ops as a single database transaction via pool.BeginTx,
+// using the given pgx.TxOptions (e.g. read-only snapshot, repeatable read,
+// serializable isolation) to control the isolation level. The transaction
+// is rolled back if any op fails.
+func (p *JSONBProvider) RunTxn(ctx context.Context, opts pgx.TxOptions, ops []*workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) error {
+	tx, err := p.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	for _, op := range ops {
+		if ok := execute(ctx, tx, p.TableName, op, data, id, rnd); !ok {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("txn op %s failed", op.Kind)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
 // GetMaxID returns the highest ID in the table.
-func (p *FuncProvider) GetMaxID() (uint64, error) {
+func (p *JSONBProvider) GetMaxID() (uint64, error) {
 	var count uint64
 	err := p.DB.QueryRow(context.Background(), "SELECT data->'id' FROM "+p.TableName+" ORDER BY data->'id' DESC LIMIT 1").Scan(&count)
 	if err != nil || count == 0 {
@@ -135,21 +324,33 @@ func (p *FuncProvider) GetMaxID() (uint64, error) {
 	return count, nil
 }
 
-// NewProvider returns an instance of FuncProvider.
-func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
-	// Connect to PostgreSQL using pgxpool
+// Provider is the common subset of operations both the JSONB and relational
+// schemas support, so the benchmark harness can drive either one the same
+// way regardless of which ?schema= was requested.
+type Provider interface {
+	InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	InsertBatch(data []*record.Person, ids []uint64) (int, error)
+	UpdateRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	ReadRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	ReadRange(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	ReadMostRecentRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	Execute(op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool
+	RunTxn(ctx context.Context, opts pgx.TxOptions, ops []*workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) error
+	GetMaxID() (uint64, error)
+}
+
+// connect opens and pings a pgxpool against endpoint.
+func connect(endpoint *url.URL) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(endpoint.String())
 	if err != nil {
 		return nil, err
 	}
 
-	// Open a pool of connections
 	pool, err := pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
 		return nil, err
 	}
 
-	// Ensure the connection pool is alive
 	if err := pool.Ping(context.Background()); err != nil {
 		return nil, err
 	}
@@ -159,8 +360,43 @@ func NewProvider(endpoint *url.URL, tableName string) (*FuncProvider, error) {
 	// https://github.com/golang/go/issues/21056
 	runtime.GOMAXPROCS(2)
 
-	// DB func provider
-	return &FuncProvider{
+	return pool, nil
+}
+
+// NewProvider returns a Provider backed by PostgreSQL. By default it stores
+// records in a single JSONB column (JSONBProvider); passing ?schema=relational
+// in endpoint instead maps record.Person onto typed columns
+// (RelationalProvider), so the two storage styles can be benchmarked
+// side by side.
+func NewProvider(endpoint *url.URL, tableName string) (Provider, error) {
+	q := endpoint.Query()
+	schema := strings.ToLower(q.Get("schema"))
+	q.Del("schema")
+	endpoint.RawQuery = q.Encode()
+
+	switch schema {
+	case "", "jsonb":
+		return newJSONBProvider(endpoint, tableName)
+	case "relational":
+		return newRelationalProvider(endpoint, tableName)
+	default:
+		return nil, fmt.Errorf("unknown schema value %q", schema)
+	}
+}
+
+// newJSONBProvider returns an instance of JSONBProvider.
+func newJSONBProvider(endpoint *url.URL, tableName string) (*JSONBProvider, error) {
+	pool, err := connect(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &migrationStore{pool: pool, tableName: tableName}
+	if err := migrate.Apply(context.Background(), store, migrations(pool, tableName)); err != nil {
+		return nil, err
+	}
+
+	return &JSONBProvider{
 		DB:        pool,
 		TableName: tableName,
 	}, nil
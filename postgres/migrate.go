@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/wardaBibi/mongo-postgres-benchmark/migrate"
+	"golang.org/x/net/context"
+)
+
+// migrationStore adapts a schema_migrations table to migrate.Store. The
+// table holds one row per benchmarked table, recording the currently
+// applied version, so the JSONB and relational schemas -- which may target
+// different table names in the same database -- track their versions
+// independently.
+type migrationStore struct {
+	pool      *pgxpool.Pool
+	tableName string
+}
+
+func (s *migrationStore) ensureTable(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (table_name text PRIMARY KEY, version text NOT NULL)`)
+	return err
+}
+
+func (s *migrationStore) CurrentVersion(ctx context.Context) (migrate.Version, error) {
+	if err := s.ensureTable(ctx); err != nil {
+		return "", err
+	}
+
+	var version string
+	err := s.pool.QueryRow(ctx, "SELECT version FROM schema_migrations WHERE table_name = $1", s.tableName).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return "", nil // no row yet: treat as version ""
+	}
+	if err != nil {
+		return "", err
+	}
+	return migrate.Version(version), nil
+}
+
+func (s *migrationStore) SetVersion(ctx context.Context, v migrate.Version) error {
+	_, err := s.pool.Exec(ctx,
+		"INSERT INTO schema_migrations (table_name, version) VALUES ($1, $2) ON CONFLICT (table_name) DO UPDATE SET version = $2",
+		s.tableName, string(v),
+	)
+	return err
+}
+
+// migrations are the built-in schema changes every fresh benchmark database
+// needs: the indexes the benchmark's operations currently assume exist
+// implicitly.
+func migrations(pool *pgxpool.Pool, tableName string) []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version:     "0001",
+			Description: "GIN index on the data jsonb column",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS "+tableName+"_data_gin_idx ON "+tableName+" USING GIN (data)")
+				return err
+			},
+		},
+		{
+			Version:     "0002",
+			Description: "btree index on (data->'id') for ReadMostRecentRecord",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS "+tableName+"_id_idx ON "+tableName+" (((data->'id')) DESC)")
+				return err
+			},
+		},
+		{
+			Version:     "0003",
+			Description: "tenant_id column plus (tenant_id, data->'id') btree index",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				if _, err := pool.Exec(ctx, "ALTER TABLE "+tableName+" ADD COLUMN IF NOT EXISTS tenant_id bigint NOT NULL DEFAULT 0"); err != nil {
+					return err
+				}
+				_, err := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS "+tableName+"_tenant_id_idx ON "+tableName+" (tenant_id, (data->'id'))")
+				return err
+			},
+		},
+	}
+}
+
+// relationalMigrations are the built-in schema changes for RelationalProvider:
+// the table itself (typed columns instead of a JSONB blob) and the btree
+// indexes a flat column layout can use directly.
+func relationalMigrations(pool *pgxpool.Pool, tableName string) []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version:     "0001",
+			Description: "create table with typed columns",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := pool.Exec(ctx, "CREATE TABLE IF NOT EXISTS "+tableName+
+					" (id bigint PRIMARY KEY, tenant_id bigint NOT NULL DEFAULT 0, age int, balance real, name text)")
+				return err
+			},
+		},
+		{
+			Version:     "0002",
+			Description: "btree index on age for ReadRange",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS "+tableName+"_age_idx ON "+tableName+" (age)")
+				return err
+			},
+		},
+		{
+			Version:     "0003",
+			Description: "btree index on (tenant_id, id) for tenant-scoped lookups",
+			Up: func(ctx context.Context, _ migrate.Version) error {
+				_, err := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS "+tableName+"_tenant_id_idx ON "+tableName+" (tenant_id, id)")
+				return err
+			},
+		},
+	}
+}
@@ -0,0 +1,293 @@
+package postgres
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net/url"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/wardaBibi/mongo-postgres-benchmark/idgen"
+	"github.com/wardaBibi/mongo-postgres-benchmark/migrate"
+	"github.com/wardaBibi/mongo-postgres-benchmark/record"
+	"github.com/wardaBibi/mongo-postgres-benchmark/workload"
+	"golang.org/x/net/context"
+)
+
+// RelationalProvider stores record.Person as typed columns instead of a
+// single JSONB blob, so btree indexes on age/tenant_id/id work the way they
+// would in any ordinary Postgres schema. This is the fair comparison point
+// against Mongo's typed _id and BSON fields; JSONBProvider's data->'field'
+// predicates can't use a plain btree index the way these columns can.
+type RelationalProvider struct {
+	DB        *pgxpool.Pool
+	TableName string
+}
+
+// InsertRecord generates a new random record and inserts it as a row with
+// one column per field.
+func (p *RelationalProvider) InsertRecord(data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	data.Randomise(rnd)
+	data.TenantID, data.ID = id.GetNew()
+
+	_, err := p.DB.Exec(context.Background(),
+		"INSERT INTO "+p.TableName+" (id, tenant_id, age, balance, name) VALUES ($1, $2, $3, $4, $5)",
+		data.ID, data.TenantID, data.Age, data.Balance, data.Name,
+	)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+// InsertBatch inserts data in a single round trip using pgx.CopyFrom,
+// assigning each record's ID positionally from ids. Callers are expected to
+// have already populated data (e.g. via Randomise and a TenantID) since,
+// unlike InsertRecord, InsertBatch has no idgen.Generator to do that itself
+// -- the load phase pre-generates IDs in bulk instead of one round trip per
+// record. COPY is what makes bulk loading millions of typed-column rows
+// storage-bound instead of network-bound.
+func (p *RelationalProvider) InsertBatch(data []*record.Person, ids []uint64) (int, error) {
+	for i, d := range data {
+		d.ID = ids[i]
+	}
+
+	n, err := p.DB.CopyFrom(
+		context.Background(),
+		pgx.Identifier{p.TableName},
+		[]string{"id", "tenant_id", "age", "balance", "name"},
+		pgx.CopyFromSlice(len(data), func(i int) ([]interface{}, error) {
+			d := data[i]
+			return []interface{}{d.ID, d.TenantID, d.Age, d.Balance, d.Name}, nil
+		}),
+	)
+	return int(n), err
+}
+
+// UpdateRecord attempts to update the record with ID returned by
+// id.GetExisting.
+func (p *RelationalProvider) UpdateRecord(_ *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	tenantID, recordID := id.GetExisting()
+	_, err := p.DB.Exec(context.Background(),
+		"UPDATE "+p.TableName+" SET balance=$1 WHERE tenant_id=$2 AND id=$3",
+		rnd.Float32(), tenantID, recordID,
+	)
+	if err != nil {
+		log.Println(tenantID, recordID, err)
+		return false
+	}
+	return true
+}
+
+// ReadRecord attempts to fetch the record with an ID returned by
+// id.GetExisting.
+func (p *RelationalProvider) ReadRecord(_ *record.Person, id idgen.Generator, _ *rand.Rand) bool {
+	tenantID, recordID := id.GetExisting()
+
+	var data record.Person
+	err := p.DB.QueryRow(context.Background(),
+		"SELECT id, tenant_id, age, balance, name FROM "+p.TableName+" WHERE tenant_id=$1 AND id=$2",
+		tenantID, recordID,
+	).Scan(&data.ID, &data.TenantID, &data.Age, &data.Balance, &data.Name)
+	if err != nil {
+		log.Println(tenantID, recordID, err)
+		return false
+	}
+	return true
+}
+
+// ReadRange performs a range query on the age column.
+func (p *RelationalProvider) ReadRange(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+	rows, err := p.DB.Query(context.Background(), "SELECT id, tenant_id, age, balance, name FROM "+p.TableName+" WHERE age > 45 AND age < 75")
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	defer rows.Close()
+
+	var data record.Person
+	for rows.Next() {
+		if err := rows.Scan(&data.ID, &data.TenantID, &data.Age, &data.Balance, &data.Name); err != nil {
+			log.Println(err)
+			return false
+		}
+	}
+	return rows.Err() == nil
+}
+
+// ReadMostRecentRecord fetches the most recently inserted record by sorting
+// on the id column.
+func (p *RelationalProvider) ReadMostRecentRecord(_ *record.Person, _ idgen.Generator, _ *rand.Rand) bool {
+	var data record.Person
+	err := p.DB.QueryRow(context.Background(),
+		"SELECT id, tenant_id, age, balance, name FROM "+p.TableName+" ORDER BY id DESC LIMIT 1",
+	).Scan(&data.ID, &data.TenantID, &data.Age, &data.Balance, &data.Name)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+// GetMaxID returns the highest ID in the table.
+func (p *RelationalProvider) GetMaxID() (uint64, error) {
+	var id uint64
+	err := p.DB.QueryRow(context.Background(), "SELECT id FROM "+p.TableName+" ORDER BY id DESC LIMIT 1").Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Execute runs a single workload.Op against the table, translating its
+// filter AST into a predicate against bare columns rather than a JSONB
+// extraction.
+func (p *RelationalProvider) Execute(op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	return executeRelational(context.Background(), p.DB, p.TableName, op, data, id, rnd)
+}
+
+func executeRelational(ctx context.Context, db querier, tableName string, op *workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) bool {
+	filter, err := workload.ParseFilter(op.Filter)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+
+	switch op.Kind {
+	case workload.OpInsert:
+		data.Randomise(rnd)
+		data.TenantID, data.ID = id.GetNew()
+		if _, err := db.Exec(ctx,
+			"INSERT INTO "+tableName+" (id, tenant_id, age, balance, name) VALUES ($1, $2, $3, $4, $5)",
+			data.ID, data.TenantID, data.Age, data.Balance, data.Name,
+		); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpFind:
+		where, args := workload.ToColumnSQL(filter, 0)
+		query := "SELECT id, tenant_id, age, balance, name FROM " + tableName + " WHERE " + where
+		if len(op.Sort) > 0 {
+			orderBy, err := workload.SortToColumnSQL(op.Sort)
+			if err != nil {
+				log.Println(err)
+				return false
+			}
+			query += " ORDER BY " + orderBy
+		}
+		if op.Limit > 0 {
+			query += " LIMIT " + strconv.Itoa(op.Limit)
+		}
+
+		rows, err := db.Query(ctx, query, args...)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer rows.Close()
+
+		var doc record.Person
+		for rows.Next() {
+			if err := rows.Scan(&doc.ID, &doc.TenantID, &doc.Age, &doc.Balance, &doc.Name); err != nil {
+				log.Println(err)
+				return false
+			}
+		}
+		return rows.Err() == nil
+
+	case workload.OpUpdate:
+		sets, err := workload.ParseUpdate(op.Update)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+
+		assignments, assignArgs := workload.ToUpdateColumnSQL(sets, 0)
+		where, whereArgs := workload.ToColumnSQL(filter, len(assignArgs))
+		query := "UPDATE " + tableName + " SET " + assignments + " WHERE " + where
+		if _, err := db.Exec(ctx, query, append(assignArgs, whereArgs...)...); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpDelete:
+		where, args := workload.ToColumnSQL(filter, 0)
+		query := "DELETE FROM " + tableName + " WHERE " + where
+		if _, err := db.Exec(ctx, query, args...); err != nil {
+			log.Println(err)
+			return false
+		}
+		return true
+
+	case workload.OpAggregate:
+		// Mirrors Mongo's $match+$limit aggregation pipeline: fetch and
+		// drain the matching rows rather than counting them, so the same
+		// workload JSON benchmarks the same operation on both backends.
+		where, args := workload.ToColumnSQL(filter, 0)
+		query := "SELECT id, tenant_id, age, balance, name FROM " + tableName + " WHERE " + where
+		if op.Limit > 0 {
+			query += " LIMIT " + strconv.Itoa(op.Limit)
+		}
+
+		rows, err := db.Query(ctx, query, args...)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+		}
+		return rows.Err() == nil
+
+	default:
+		log.Printf("unsupported workload op kind: %s", op.Kind)
+		return false
+	}
+}
+
+// RunTxn executes ops as a single database transaction via pool.BeginTx,
+// mirroring JSONBProvider.RunTxn but against typed columns.
+func (p *RelationalProvider) RunTxn(ctx context.Context, opts pgx.TxOptions, ops []*workload.Op, data *record.Person, id idgen.Generator, rnd *rand.Rand) error {
+	tx, err := p.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	for _, op := range ops {
+		if ok := executeRelational(ctx, tx, p.TableName, op, data, id, rnd); !ok {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("txn op %s failed", op.Kind)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// newRelationalProvider returns an instance of RelationalProvider, creating
+// the table and its indexes if they don't already exist.
+func newRelationalProvider(endpoint *url.URL, tableName string) (*RelationalProvider, error) {
+	pool, err := connect(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &migrationStore{pool: pool, tableName: tableName}
+	if err := migrate.Apply(context.Background(), store, relationalMigrations(pool, tableName)); err != nil {
+		return nil, err
+	}
+
+	return &RelationalProvider{
+		DB:        pool,
+		TableName: tableName,
+	}, nil
+}
@@ -0,0 +1,25 @@
+// Package record defines the document/row shape used throughout the
+// benchmark.
+package record
+
+import "math/rand"
+
+var firstNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi"}
+
+// Person is the record type inserted, read, and updated by both the Mongo
+// and Postgres providers.
+type Person struct {
+	ID       uint64  `bson:"_id" json:"id"`
+	TenantID uint64  `bson:"tenantID" json:"tenantID"`
+	Name     string  `bson:"name" json:"name"`
+	Age      int     `bson:"age" json:"age"`
+	Balance  float32 `bson:"balance" json:"balance"`
+}
+
+// Randomise fills in a new random Name/Age/Balance, leaving ID and TenantID
+// untouched since those are assigned by an idgen.Generator.
+func (p *Person) Randomise(rnd *rand.Rand) {
+	p.Name = firstNames[rnd.Intn(len(firstNames))]
+	p.Age = rnd.Intn(100)
+	p.Balance = rnd.Float32() * 10000
+}
@@ -0,0 +1,64 @@
+package workload
+
+import "go.mongodb.org/mongo-driver/bson"
+
+var cmpOperators = map[string]string{
+	"eq":  "$eq",
+	"gt":  "$gt",
+	"gte": "$gte",
+	"lt":  "$lt",
+	"lte": "$lte",
+	"ne":  "$ne",
+}
+
+// ToBSON translates a filter Node into a bson.M suitable for Collection.Find,
+// UpdateOne, or DeleteOne.
+func ToBSON(n Node) bson.M {
+	switch v := n.(type) {
+	case nil:
+		return bson.M{}
+	case And:
+		clauses := make([]bson.M, len(v))
+		for i, child := range v {
+			clauses[i] = ToBSON(child)
+		}
+		return bson.M{"$and": clauses}
+	case Or:
+		clauses := make([]bson.M, len(v))
+		for i, child := range v {
+			clauses[i] = ToBSON(child)
+		}
+		return bson.M{"$or": clauses}
+	case Cmp:
+		return bson.M{v.Field: bson.M{cmpOperators[v.Op]: v.Value}}
+	default:
+		panic("workload: unknown filter node type")
+	}
+}
+
+// ToUpdateBSON translates a canonical update (field/value assignments) into
+// the $set document UpdateOne/UpdateMany expect.
+func ToUpdateBSON(sets []Set) bson.M {
+	fields := bson.M{}
+	for _, s := range sets {
+		fields[s.Field] = s.Value
+	}
+	return bson.M{"$set": fields}
+}
+
+// SortToBSON translates a []SortField into the bson.D expected by
+// options.Find().SetSort / options.FindOne().SetSort.
+func SortToBSON(fields []SortField) (bson.D, error) {
+	d := make(bson.D, len(fields))
+	for i, f := range fields {
+		if err := ValidateIdentifier(f.Field); err != nil {
+			return nil, err
+		}
+		dir := 1
+		if f.Desc {
+			dir = -1
+		}
+		d[i] = bson.E{Key: f.Field, Value: dir}
+	}
+	return d, nil
+}
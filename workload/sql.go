@@ -0,0 +1,162 @@
+package workload
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sqlOperators = map[string]string{
+	"eq":  "=",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+	"ne":  "!=",
+}
+
+// ToSQL translates a filter Node into a parameterized WHERE-clause fragment
+// (without the "WHERE" keyword) against the JSONB data column, plus the
+// positional arguments to pass alongside it. Placeholders start at $1; pass
+// a non-zero argOffset to continue numbering after args already bound
+// earlier in the statement (e.g. an UPDATE's SET clause).
+func ToSQL(n Node, argOffset int) (string, []interface{}) {
+	var args []interface{}
+	clause := toSQL(n, argOffset, &args)
+	return clause, args
+}
+
+func toSQL(n Node, argOffset int, args *[]interface{}) string {
+	switch v := n.(type) {
+	case nil:
+		return "TRUE"
+	case And:
+		return joinSQL(v, " AND ", argOffset, args)
+	case Or:
+		return joinSQL(v, " OR ", argOffset, args)
+	case Cmp:
+		*args = append(*args, v.Value)
+		placeholder := "$" + strconv.Itoa(argOffset+len(*args))
+		// Numeric comparisons need an explicit cast: JSONB text extraction
+		// (->>) always yields text, and `(data->>'age') > 45` would be a
+		// type error without it.
+		switch v.Value.(type) {
+		case float64, int, int64:
+			return fmt.Sprintf("(data->>'%s')::numeric %s %s", v.Field, sqlOperators[v.Op], placeholder)
+		default:
+			return fmt.Sprintf("(data->>'%s') %s %s", v.Field, sqlOperators[v.Op], placeholder)
+		}
+	default:
+		panic("workload: unknown filter node type")
+	}
+}
+
+func joinSQL(nodes []Node, sep string, argOffset int, args *[]interface{}) string {
+	// argOffset is passed through unchanged: the leaf case already derives
+	// its placeholder from argOffset+len(*args), the live length of the
+	// shared args slice, so re-adding len(*args) here would double-count
+	// args appended by earlier siblings.
+	parts := make([]string, len(nodes))
+	for i, child := range nodes {
+		parts[i] = toSQL(child, argOffset, args)
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// ToUpdateSQL translates a canonical update into a JSONB merge patch and its
+// single positional argument, against the JSONB data column. Placeholders
+// continue numbering from argOffset so the patch can be combined with a
+// ToSQL WHERE clause in the same statement.
+func ToUpdateSQL(sets []Set, argOffset int) (string, []interface{}) {
+	fields := make(map[string]interface{}, len(sets))
+	for _, s := range sets {
+		fields[s.Field] = s.Value
+	}
+	patch, _ := json.Marshal(fields)
+	return fmt.Sprintf("data || $%d::jsonb", argOffset+1), []interface{}{string(patch)}
+}
+
+// SortToSQL translates a []SortField into an "ORDER BY ..." clause
+// (without the "ORDER BY" keywords) against the JSONB data column.
+func SortToSQL(fields []SortField) (string, error) {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if err := ValidateIdentifier(f.Field); err != nil {
+			return "", err
+		}
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts[i] = fmt.Sprintf("(data->>'%s') %s", f.Field, dir)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// ToColumnSQL is ToSQL's counterpart for a typed-column schema: it compares
+// bare column names instead of extracting them out of a JSONB data column,
+// so no cast is needed.
+func ToColumnSQL(n Node, argOffset int) (string, []interface{}) {
+	var args []interface{}
+	clause := toColumnSQL(n, argOffset, &args)
+	return clause, args
+}
+
+func toColumnSQL(n Node, argOffset int, args *[]interface{}) string {
+	switch v := n.(type) {
+	case nil:
+		return "TRUE"
+	case And:
+		return joinColumnSQL(v, " AND ", argOffset, args)
+	case Or:
+		return joinColumnSQL(v, " OR ", argOffset, args)
+	case Cmp:
+		*args = append(*args, v.Value)
+		placeholder := "$" + strconv.Itoa(argOffset+len(*args))
+		return fmt.Sprintf("%s %s %s", v.Field, sqlOperators[v.Op], placeholder)
+	default:
+		panic("workload: unknown filter node type")
+	}
+}
+
+func joinColumnSQL(nodes []Node, sep string, argOffset int, args *[]interface{}) string {
+	// See joinSQL: argOffset is passed through unchanged, not re-added to
+	// len(*args), since the leaf case already derives its placeholder from
+	// the live length of the shared args slice.
+	parts := make([]string, len(nodes))
+	for i, child := range nodes {
+		parts[i] = toColumnSQL(child, argOffset, args)
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// ToUpdateColumnSQL is ToUpdateSQL's counterpart for a typed-column schema:
+// it produces a "col = $1, col2 = $2" SET-clause fragment against bare
+// columns instead of a JSONB merge patch. Fields were already validated by
+// ParseUpdate, so they're safe to splice directly.
+func ToUpdateColumnSQL(sets []Set, argOffset int) (string, []interface{}) {
+	assignments := make([]string, len(sets))
+	args := make([]interface{}, len(sets))
+	for i, s := range sets {
+		assignments[i] = fmt.Sprintf("%s = $%d", s.Field, argOffset+i+1)
+		args[i] = s.Value
+	}
+	return strings.Join(assignments, ", "), args
+}
+
+// SortToColumnSQL is SortToSQL's counterpart for a typed-column schema.
+func SortToColumnSQL(fields []SortField) (string, error) {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if err := ValidateIdentifier(f.Field); err != nil {
+			return "", err
+		}
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts[i] = fmt.Sprintf("%s %s", f.Field, dir)
+	}
+	return strings.Join(parts, ", "), nil
+}
@@ -0,0 +1,227 @@
+// Package workload describes benchmark operations declaratively, as a JSON
+// document, instead of hard-coding a fixed set of queries in each provider.
+// It is intentionally small: a query AST (And/Or/comparisons), translators
+// from that AST into provider-native query shapes (bson.M for MongoDB,
+// parameterized SQL for Postgres' JSONB column), and a weighted picker that
+// drives the benchmark loop from the configured operation mix.
+package workload
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+)
+
+// OpKind identifies the kind of operation a single workload entry performs.
+type OpKind string
+
+const (
+	OpInsert    OpKind = "insert"
+	OpFind      OpKind = "find"
+	OpUpdate    OpKind = "update"
+	OpDelete    OpKind = "delete"
+	OpAggregate OpKind = "aggregate"
+)
+
+// SortField is a single key in a sort specification, e.g. {"field": "_id", "desc": true}.
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// identifierPattern is what a field/column name coming out of workload JSON
+// must match before it's safe to splice into a SQL clause: translators
+// build queries with string concatenation rather than bind parameters for
+// field names (bind parameters can only stand in for values), so anything
+// else has to be rejected up front rather than quoted.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateIdentifier reports an error if field isn't a safe bare identifier
+// (letters, digits, underscore, not starting with a digit). Every
+// translator that splices a field/column name into a query -- rather than
+// binding it as a parameter -- must call this first.
+func ValidateIdentifier(field string) error {
+	if !identifierPattern.MatchString(field) {
+		return fmt.Errorf("workload: %q is not a valid field name", field)
+	}
+	return nil
+}
+
+// Op is a single operation entry loaded from the workload JSON file. Filter
+// and Update are decoded lazily (via ParseFilter into a Node tree and
+// ParseUpdate into []Set) so they can be shared between the Mongo and
+// Postgres translators.
+type Op struct {
+	Kind       OpKind          `json:"kind"`
+	Filter     json.RawMessage `json:"filter,omitempty"`
+	Update     json.RawMessage `json:"update,omitempty"`
+	Projection []string        `json:"projection,omitempty"`
+	Sort       []SortField     `json:"sort,omitempty"`
+	Limit      int             `json:"limit,omitempty"`
+	Weight     float64         `json:"weight"`
+}
+
+// Node is a node in the filter AST. Concrete types are And, Or, and Cmp.
+type Node interface {
+	node()
+}
+
+// And requires every child node to match.
+type And []Node
+
+// Or requires at least one child node to match.
+type Or []Node
+
+// Cmp is a leaf comparison against a single field, e.g. {"gt": ["age", 45]}.
+type Cmp struct {
+	Op    string // "eq", "gt", "gte", "lt", "lte", "ne"
+	Field string
+	Value interface{}
+}
+
+func (And) node() {}
+func (Or) node()  {}
+func (Cmp) node() {}
+
+// Set is a single field/value assignment parsed from an Op's Update
+// document, e.g. {"set":[["balance",12.3]]} decodes to one
+// Set{Field: "balance", Value: 12.3}.
+type Set struct {
+	Field string
+	Value interface{}
+}
+
+// ParseFilter decodes a filter document such as
+// {"and":[{"gt":["age",45]},{"lt":["age",75]}]} into a Node tree.
+func ParseFilter(raw json.RawMessage) (Node, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("workload: invalid filter %q: %w", raw, err)
+	}
+	if len(obj) != 1 {
+		return nil, fmt.Errorf("workload: filter must have exactly one key, got %d", len(obj))
+	}
+
+	for key, val := range obj {
+		switch key {
+		case "and", "or":
+			var children []json.RawMessage
+			if err := json.Unmarshal(val, &children); err != nil {
+				return nil, fmt.Errorf("workload: %q must be an array: %w", key, err)
+			}
+			nodes := make([]Node, 0, len(children))
+			for _, c := range children {
+				n, err := ParseFilter(c)
+				if err != nil {
+					return nil, err
+				}
+				nodes = append(nodes, n)
+			}
+			if key == "and" {
+				return And(nodes), nil
+			}
+			return Or(nodes), nil
+
+		case "eq", "gt", "gte", "lt", "lte", "ne":
+			var pair [2]interface{}
+			if err := json.Unmarshal(val, &pair); err != nil {
+				return nil, fmt.Errorf("workload: %q must be [field, value]: %w", key, err)
+			}
+			field, ok := pair[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("workload: %q field must be a string", key)
+			}
+			if err := ValidateIdentifier(field); err != nil {
+				return nil, err
+			}
+			return Cmp{Op: key, Field: field, Value: pair[1]}, nil
+
+		default:
+			return nil, fmt.Errorf("workload: unknown filter operator %q", key)
+		}
+	}
+
+	panic("unreachable")
+}
+
+// ParseUpdate decodes an update document such as
+// {"set":[["balance",12.3],["name","alice"]]} into a canonical list of
+// field/value assignments, the same way ParseFilter turns a filter document
+// into a Node tree -- so Mongo and Postgres can each translate the same
+// workload JSON into their own native update syntax instead of requiring a
+// backend-specific Update shape.
+func ParseUpdate(raw json.RawMessage) ([]Set, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var doc struct {
+		Set [][2]interface{} `json:"set"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("workload: invalid update %q: %w", raw, err)
+	}
+
+	sets := make([]Set, len(doc.Set))
+	for i, pair := range doc.Set {
+		field, ok := pair[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("workload: update field must be a string")
+		}
+		if err := ValidateIdentifier(field); err != nil {
+			return nil, err
+		}
+		sets[i] = Set{Field: field, Value: pair[1]}
+	}
+	return sets, nil
+}
+
+// Engine holds a loaded operation mix and picks operations according to
+// their configured Weight.
+type Engine struct {
+	Ops   []*Op
+	total float64
+}
+
+// Load reads a workload definition (a JSON array of Op entries) from path.
+func Load(path string) (*Engine, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("workload: reading %s: %w", path, err)
+	}
+
+	var ops []*Op
+	if err := json.Unmarshal(raw, &ops); err != nil {
+		return nil, fmt.Errorf("workload: parsing %s: %w", path, err)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("workload: %s defines no operations", path)
+	}
+
+	e := &Engine{Ops: ops}
+	for _, op := range ops {
+		if op.Weight <= 0 {
+			return nil, fmt.Errorf("workload: operation %q has non-positive weight", op.Kind)
+		}
+		e.total += op.Weight
+	}
+	return e, nil
+}
+
+// Pick returns a random operation, chosen proportionally to its Weight.
+func (e *Engine) Pick(rnd *rand.Rand) *Op {
+	target := rnd.Float64() * e.total
+	for _, op := range e.Ops {
+		target -= op.Weight
+		if target <= 0 {
+			return op
+		}
+	}
+	return e.Ops[len(e.Ops)-1]
+}